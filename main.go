@@ -1,18 +1,46 @@
 package main
 
 import (
+	"bufio"
+	"crypto/rand"
 	"database/sql"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// quoteIdent safely quotes a single SQL identifier (role name, database
+// name, schema, table, etc.) for interpolation into DDL that can't use a
+// bind parameter.
+func quoteIdent(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
+
+// quoteGrantee renders a GRANT/ALTER DEFAULT PRIVILEGES target. aclexplode()
+// represents the PUBLIC pseudo-role as grantee oid 0, which GetObjectGrants
+// and GetDefaultPrivileges translate to the literal string "PUBLIC" rather
+// than casting it through regrole (oid 0 ::regrole::text renders as "-").
+// PUBLIC is a keyword, not an identifier, so it must not be quoted.
+func quoteGrantee(grantee string) string {
+	if grantee == "PUBLIC" {
+		return "PUBLIC"
+	}
+	return quoteIdent(grantee)
+}
+
 type Config struct {
 	SrcHost     string
 	SrcPort     int
@@ -23,6 +51,57 @@ type Config struct {
 	DstUser     string
 	DstPassword string
 	DumpDir     string
+
+	Resume        bool
+	OnlyPhases    string
+	OnlyDatabases string
+	SkipDatabases string
+
+	Mode            string
+	LogicalLagBytes uint64
+	Cutover         bool
+
+	JobsDatabases   int
+	JobsPerDatabase int
+	Pipe            bool
+
+	Verify       bool
+	VerifyTables string
+	ReportFile   string
+
+	SrcURI         string
+	SrcSSLMode     string
+	SrcSSLRootCert string
+	SrcSSLCert     string
+	SrcSSLKey      string
+
+	DstURI         string
+	DstSSLMode     string
+	DstSSLRootCert string
+	DstSSLCert     string
+	DstSSLKey      string
+
+	ChannelBinding string
+
+	HookDir             string
+	ContinueOnHookError bool
+}
+
+// Replication slot names are unique cluster-wide (they live on the source
+// server, not inside any one database), so the publication/subscription/
+// slot names used by --mode=logical are derived per database rather than
+// shared constants; otherwise migrating a second database would fail with
+// "replication slot already exists".
+func logicalPublicationName(dbname string) string {
+	return "pg_migrator_pub_" + dbname
+}
+
+func logicalSubscriptionName(dbname string) string {
+	return "pg_migrator_sub_" + dbname
+}
+
+func logicalSlotName(dbname string) string {
+	return "pg_migrator_slot_" + dbname
 }
 
 type Role struct {
@@ -38,51 +117,212 @@ type Role struct {
 }
 
 type Migrator struct {
-	config    Config
-	srcConn   *sql.DB
-	dstConn   *sql.DB
+	config  Config
+	srcConn *sql.DB
+	dstConn *sql.DB
+
+	runID       string
+	onlyPhases  map[string]bool
+	onlyDB      map[string]bool
+	skipDB      map[string]bool
+	resumeDone  map[string]bool
+
+	hooks Hooks
+}
+
+// Hooks describes the pre/post command hooks an operator can drop into
+// --hook-dir to run custom SQL or shell commands around a migration
+// phase, following the same idea as trek's RunHook. Dir is empty when
+// hooks are disabled.
+type Hooks struct {
+	Dir             string
+	ContinueOnError bool
+}
+
+// parseCSVSet splits a comma-separated flag value into a lookup set,
+// trimming whitespace and dropping empty entries. An empty input yields
+// an empty (nil) set, meaning "no restriction".
+func parseCSVSet(s string) map[string]bool {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	set := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			set[part] = true
+		}
+	}
+	return set
+}
+
+// newRunID generates a random UUIDv4 to tag every state row written by
+// this invocation. crypto/rand only fails if the OS entropy source is
+// unavailable, which isn't a case worth plumbing an error return for here.
+func newRunID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
 }
 
 func NewMigrator(config Config) *Migrator {
 	return &Migrator{
-		config: config,
+		config:     config,
+		runID:      newRunID(),
+		onlyPhases: parseCSVSet(config.OnlyPhases),
+		onlyDB:     parseCSVSet(config.OnlyDatabases),
+		skipDB:     parseCSVSet(config.SkipDatabases),
+		hooks: Hooks{
+			Dir:             config.HookDir,
+			ContinueOnError: config.ContinueOnHookError,
+		},
 	}
 }
 
+// runPhase is enabled when --only wasn't passed (nil set means "run
+// everything") or explicitly names phase.
+func (m *Migrator) runPhase(phase string) bool {
+	return m.onlyPhases == nil || m.onlyPhases[phase]
+}
+
+// filterDatabases applies --only-db / --skip-db to a database list.
+func (m *Migrator) filterDatabases(databases []string) []string {
+	var filtered []string
+	for _, dbname := range databases {
+		if m.onlyDB != nil && !m.onlyDB[dbname] {
+			continue
+		}
+		if m.skipDB != nil && m.skipDB[dbname] {
+			continue
+		}
+		filtered = append(filtered, dbname)
+	}
+	return filtered
+}
+
 func (m *Migrator) Connect() error {
 	var err error
-	
+
 	// Connect to source
-	srcConnStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=postgres sslmode=disable",
-		m.config.SrcHost, m.config.SrcPort, m.config.SrcUser, m.config.SrcPassword)
-	
-	m.srcConn, err = sql.Open("postgres", srcConnStr)
+	m.srcConn, err = m.connectSrcDB("postgres")
 	if err != nil {
 		return fmt.Errorf("failed to connect to source: %w", err)
 	}
-	
-	if err = m.srcConn.Ping(); err != nil {
-		return fmt.Errorf("failed to ping source: %w", err)
-	}
 	log.Println("✓ Connected to source server")
-	
+
 	// Connect to destination
-	dstConnStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=postgres sslmode=disable",
-		m.config.DstHost, m.config.DstPort, m.config.DstUser, m.config.DstPassword)
-	
-	m.dstConn, err = sql.Open("postgres", dstConnStr)
+	m.dstConn, err = m.connectDstDB("postgres")
 	if err != nil {
 		return fmt.Errorf("failed to connect to destination: %w", err)
 	}
-	
-	if err = m.dstConn.Ping(); err != nil {
-		return fmt.Errorf("failed to ping destination: %w", err)
-	}
 	log.Println("✓ Connected to destination server")
-	
+
+	if err := m.ensureStateTable(); err != nil {
+		return fmt.Errorf("failed to set up state tracking: %w", err)
+	}
+
+	if m.config.Resume {
+		if err := m.loadResumeState(); err != nil {
+			return fmt.Errorf("failed to load resume state: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ensureStateTable creates pg_migrator_state on the destination's
+// "postgres" database if it doesn't already exist. Every checkpoint
+// written by MigrateRoles/CreateDatabase/DumpDatabase/RestoreDatabase
+// lives here so interrupted runs can be resumed with --resume.
+func (m *Migrator) ensureStateTable() error {
+	_, err := m.dstConn.Exec(`
+		CREATE TABLE IF NOT EXISTS pg_migrator_state (
+			run_id      uuid NOT NULL,
+			phase       text NOT NULL,
+			object      text NOT NULL,
+			status      text NOT NULL,
+			started_at  timestamptz NOT NULL DEFAULT now(),
+			finished_at timestamptz,
+			error       text
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create pg_migrator_state: %w", err)
+	}
 	return nil
 }
 
+// loadResumeState records every (phase, object) pair that has ever
+// finished in pg_migrator_state, across all prior run_ids, so
+// MigrateRoles/MigrateDatabases can skip them. It deliberately isn't
+// scoped to the single most recent prior run: a run resumed more than
+// once must still see everything completed by its whole lineage of
+// ancestors, not just the last one.
+func (m *Migrator) loadResumeState() error {
+	query := `
+		SELECT phase, object
+		FROM pg_migrator_state
+		WHERE status = 'done'
+		AND run_id != $1;
+	`
+
+	rows, err := m.dstConn.Query(query, m.runID)
+	if err != nil {
+		return fmt.Errorf("failed to query prior run state: %w", err)
+	}
+	defer rows.Close()
+
+	m.resumeDone = make(map[string]bool)
+	for rows.Next() {
+		var phase, object string
+		if err := rows.Scan(&phase, &object); err != nil {
+			return fmt.Errorf("failed to scan prior run state: %w", err)
+		}
+		m.resumeDone[phase+"|"+object] = true
+	}
+
+	log.Printf("Resuming: %d objects already completed in a previous run", len(m.resumeDone))
+	return nil
+}
+
+// alreadyDone reports whether (phase, object) was marked done by any
+// prior run loaded by --resume, so the caller can skip redoing it.
+func (m *Migrator) alreadyDone(phase, object string) bool {
+	return m.resumeDone[phase+"|"+object]
+}
+
+func (m *Migrator) recordRunning(phase, object string) {
+	_, err := m.dstConn.Exec(
+		`INSERT INTO pg_migrator_state (run_id, phase, object, status) VALUES ($1, $2, $3, 'running')`,
+		m.runID, phase, object)
+	if err != nil {
+		log.Printf("Warning: failed to record state for %s %s: %v", phase, object, err)
+	}
+}
+
+func (m *Migrator) recordDone(phase, object string) {
+	_, err := m.dstConn.Exec(
+		`UPDATE pg_migrator_state SET status = 'done', finished_at = now()
+		 WHERE run_id = $1 AND phase = $2 AND object = $3`,
+		m.runID, phase, object)
+	if err != nil {
+		log.Printf("Warning: failed to record state for %s %s: %v", phase, object, err)
+	}
+}
+
+func (m *Migrator) recordFailed(phase, object string, cause error) {
+	_, err := m.dstConn.Exec(
+		`UPDATE pg_migrator_state SET status = 'failed', finished_at = now(), error = $4
+		 WHERE run_id = $1 AND phase = $2 AND object = $3`,
+		m.runID, phase, object, cause.Error())
+	if err != nil {
+		log.Printf("Warning: failed to record state for %s %s: %v", phase, object, err)
+	}
+}
+
 func (m *Migrator) Close() {
 	if m.srcConn != nil {
 		m.srcConn.Close()
@@ -92,15 +332,17 @@ func (m *Migrator) Close() {
 	}
 }
 
-func (m *Migrator) GetRoles() ([]Role, error) {
+// rolesFromConn runs the role inventory query against conn, so it can be
+// pointed at either the source or destination server (Verify needs both).
+func rolesFromConn(conn *sql.DB) ([]Role, error) {
 	query := `
-		SELECT 
-			rolname, 
-			rolsuper, 
-			rolinherit, 
-			rolcreaterole, 
-			rolcreatedb, 
-			rolcanlogin, 
+		SELECT
+			rolname,
+			rolsuper,
+			rolinherit,
+			rolcreaterole,
+			rolcreatedb,
+			rolcanlogin,
 			rolreplication,
 			rolconnlimit,
 			rolvaliduntil
@@ -112,27 +354,31 @@ func (m *Migrator) GetRoles() ([]Role, error) {
 		AND rolname NOT LIKE 'pg_%'
 		ORDER BY rolname;
 	`
-	
-	rows, err := m.srcConn.Query(query)
+
+	rows, err := conn.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query roles: %w", err)
 	}
 	defer rows.Close()
-	
+
 	var roles []Role
 	for rows.Next() {
 		var r Role
-		err := rows.Scan(&r.Name, &r.Super, &r.Inherit, &r.CreateRole, 
+		err := rows.Scan(&r.Name, &r.Super, &r.Inherit, &r.CreateRole,
 			&r.CreateDB, &r.CanLogin, &r.Replication, &r.ConnLimit, &r.ValidUntil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan role: %w", err)
 		}
 		roles = append(roles, r)
 	}
-	
+
 	return roles, nil
 }
 
+func (m *Migrator) GetRoles() ([]Role, error) {
+	return rolesFromConn(m.srcConn)
+}
+
 func (m *Migrator) GetRolePasswords() (map[string]string, error) {
 	query := `
 		SELECT rolname, rolpassword
@@ -160,6 +406,13 @@ func (m *Migrator) GetRolePasswords() (map[string]string, error) {
 	return passwords, nil
 }
 
+// quoteLiteral escapes a string for use as a SQL string literal in
+// statements (CREATE ROLE ... PASSWORD, VALID UNTIL, GRANT, etc.) where
+// a bind parameter isn't available because the value sits inside DDL.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 func (m *Migrator) RoleExists(roleName string) (bool, error) {
 	var exists bool
 	query := "SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)"
@@ -180,7 +433,7 @@ func (m *Migrator) CreateRole(role Role, password string) error {
 	
 	// Build CREATE ROLE statement
 	var stmt strings.Builder
-	fmt.Fprintf(&stmt, `CREATE ROLE "%s"`, role.Name)
+	fmt.Fprintf(&stmt, "CREATE ROLE %s", quoteIdent(role.Name))
 	
 	var options []string
 	
@@ -229,11 +482,11 @@ func (m *Migrator) CreateRole(role Role, password string) error {
 	}
 	
 	if password != "" {
-		fmt.Fprintf(&stmt, " PASSWORD '%s'", password)
+		fmt.Fprintf(&stmt, " PASSWORD %s", quoteLiteral(password))
 	}
-	
+
 	if role.ValidUntil.Valid {
-		fmt.Fprintf(&stmt, " VALID UNTIL '%s'", role.ValidUntil.String)
+		fmt.Fprintf(&stmt, " VALID UNTIL %s", quoteLiteral(role.ValidUntil.String))
 	}
 	
 	_, err = m.dstConn.Exec(stmt.String())
@@ -247,44 +500,65 @@ func (m *Migrator) CreateRole(role Role, password string) error {
 
 func (m *Migrator) MigrateRoles() error {
 	log.Println("\n=== Migrating Roles ===")
-	
+
+	if err := m.runHook("pre-roles", "", "postgres"); err != nil {
+		return fmt.Errorf("pre-roles hook failed: %w", err)
+	}
+
 	roles, err := m.GetRoles()
 	if err != nil {
 		return err
 	}
-	
+
 	passwords, err := m.GetRolePasswords()
 	if err != nil {
 		return err
 	}
-	
+
 	log.Printf("Found %d roles to migrate", len(roles))
-	
+
 	for _, role := range roles {
+		if m.alreadyDone("roles", role.Name) {
+			log.Printf("Skipping role %s (already done in a previous run)", role.Name)
+			continue
+		}
+
+		m.recordRunning("roles", role.Name)
+
 		password := passwords[role.Name]
 		if err := m.CreateRole(role, password); err != nil {
 			log.Printf("⨯ Failed to create role %s: %v", role.Name, err)
+			m.recordFailed("roles", role.Name, err)
+			continue
 		}
+
+		m.recordDone("roles", role.Name)
 	}
-	
+
+	if err := m.runHook("post-roles", "", "postgres"); err != nil {
+		return fmt.Errorf("post-roles hook failed: %w", err)
+	}
+
 	return nil
 }
 
-func (m *Migrator) GetDatabases() ([]string, error) {
+// databasesFromConn runs the database inventory query against conn, so
+// it can be pointed at either the source or destination server.
+func databasesFromConn(conn *sql.DB) ([]string, error) {
 	query := `
-		SELECT datname 
-		FROM pg_database 
+		SELECT datname
+		FROM pg_database
 		WHERE datname NOT IN ('postgres', 'template0', 'template1')
 		AND datistemplate = false
 		ORDER BY datname;
 	`
-	
-	rows, err := m.srcConn.Query(query)
+
+	rows, err := conn.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query databases: %w", err)
 	}
 	defer rows.Close()
-	
+
 	var databases []string
 	for rows.Next() {
 		var dbname string
@@ -293,26 +567,36 @@ func (m *Migrator) GetDatabases() ([]string, error) {
 		}
 		databases = append(databases, dbname)
 	}
-	
+
 	return databases, nil
 }
 
-func (m *Migrator) GetDatabaseOwner(dbname string) (string, error) {
+func (m *Migrator) GetDatabases() ([]string, error) {
+	return databasesFromConn(m.srcConn)
+}
+
+// databaseOwnerFromConn runs the owner lookup against conn, so it can be
+// pointed at either the source or destination server.
+func databaseOwnerFromConn(conn *sql.DB, dbname string) (string, error) {
 	query := `
 		SELECT pg_catalog.pg_get_userbyid(d.datdba) as owner
 		FROM pg_catalog.pg_database d
 		WHERE d.datname = $1;
 	`
-	
+
 	var owner string
-	err := m.srcConn.QueryRow(query, dbname).Scan(&owner)
+	err := conn.QueryRow(query, dbname).Scan(&owner)
 	if err != nil {
 		return "", fmt.Errorf("failed to get database owner: %w", err)
 	}
-	
+
 	return owner, nil
 }
 
+func (m *Migrator) GetDatabaseOwner(dbname string) (string, error) {
+	return databaseOwnerFromConn(m.srcConn, dbname)
+}
+
 func (m *Migrator) DatabaseExists(dbname string) (bool, error) {
 	var exists bool
 	query := "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)"
@@ -328,15 +612,15 @@ func (m *Migrator) CreateDatabase(dbname, owner string) error {
 	
 	if exists {
 		log.Printf("Database %s already exists, dropping...", dbname)
-		_, err := m.dstConn.Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS "%s"`, dbname))
+		_, err := m.dstConn.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", quoteIdent(dbname)))
 		if err != nil {
 			return fmt.Errorf("failed to drop database: %w", err)
 		}
 	}
-	
-	stmt := fmt.Sprintf(`CREATE DATABASE "%s"`, dbname)
+
+	stmt := fmt.Sprintf("CREATE DATABASE %s", quoteIdent(dbname))
 	if owner != "" {
-		stmt += fmt.Sprintf(` OWNER "%s"`, owner)
+		stmt += fmt.Sprintf(" OWNER %s", quoteIdent(owner))
 	}
 	
 	_, err = m.dstConn.Exec(stmt)
@@ -348,130 +632,1417 @@ func (m *Migrator) CreateDatabase(dbname, owner string) error {
 	return nil
 }
 
-func (m *Migrator) DumpDatabase(dbname, dumpFile string) error {
+// streamCommand starts cmd and copies its stderr, line by line, into the
+// structured logger under label, instead of buffering it all with
+// CombinedOutput — which hides progress until the process exits and can
+// OOM on multi-gigabyte dumps.
+func streamCommand(cmd *exec.Cmd, label string) error {
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to attach stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", label, err)
+	}
+
+	logLines(label, stderr)
+
+	return cmd.Wait()
+}
+
+func logLines(label string, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		log.Printf("[%s] %s", label, scanner.Text())
+	}
+}
+
+// DumpDatabase runs pg_dump in directory format with --jobs-per-database
+// parallel workers, so large databases dump significantly faster than
+// the single-threaded custom format allows.
+func (m *Migrator) DumpDatabase(dbname, dumpDir string) error {
 	log.Printf("Dumping database: %s", dbname)
-	
+
 	cmd := exec.Command("pg_dump",
 		"-h", m.config.SrcHost,
 		"-p", fmt.Sprintf("%d", m.config.SrcPort),
 		"-U", m.config.SrcUser,
-		"-F", "c", // Custom format
-		"-b",      // Include large objects
-		"-v",      // Verbose
-		"-f", dumpFile,
+		"-F", "d", // Directory format, required for -j
+		"-j", fmt.Sprintf("%d", m.config.JobsPerDatabase),
+		"-b", // Include large objects
+		"-v", // Verbose
+		"-f", dumpDir,
 		dbname,
 	)
-	
-	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", m.config.SrcPassword))
-	
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("pg_dump failed: %w\n%s", err, string(output))
+
+	cmd.Env = m.srcExecEnv()
+
+	if err := streamCommand(cmd, fmt.Sprintf("pg_dump:%s", dbname)); err != nil {
+		return fmt.Errorf("pg_dump failed: %w", err)
 	}
-	
-	log.Printf("✓ Dumped %s to %s", dbname, dumpFile)
+
+	log.Printf("✓ Dumped %s to %s", dbname, dumpDir)
 	return nil
 }
 
-func (m *Migrator) RestoreDatabase(dbname, dumpFile string) error {
+func (m *Migrator) RestoreDatabase(dbname, dumpDir string) error {
 	log.Printf("Restoring database: %s", dbname)
-	
+
 	cmd := exec.Command("pg_restore",
 		"-h", m.config.DstHost,
 		"-p", fmt.Sprintf("%d", m.config.DstPort),
 		"-U", m.config.DstUser,
 		"-d", dbname,
+		"-j", fmt.Sprintf("%d", m.config.JobsPerDatabase),
 		"-v",
 		"--no-owner", // Don't set ownership (we already created with correct owner)
 		"--no-acl",   // Don't restore access privileges
-		dumpFile,
+		dumpDir,
 	)
-	
-	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", m.config.DstPassword))
-	
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("pg_restore failed: %w\n%s", err, string(output))
+
+	cmd.Env = m.dstExecEnv()
+
+	if err := streamCommand(cmd, fmt.Sprintf("pg_restore:%s", dbname)); err != nil {
+		return fmt.Errorf("pg_restore failed: %w", err)
 	}
-	
+
 	log.Printf("✓ Restored %s", dbname)
 	return nil
 }
 
-func (m *Migrator) MigrateDatabases() error {
-	databases, err := m.GetDatabases()
+// PipeDatabase connects pg_dump's stdout directly to pg_restore's stdin
+// via io.Pipe, skipping the temp dump file entirely. Used when disk is
+// too scarce to hold a full dump alongside the live databases.
+func (m *Migrator) PipeDatabase(dbname string) error {
+	log.Printf("Piping database: %s", dbname)
+
+	pr, pw := io.Pipe()
+
+	dumpCmd := exec.Command("pg_dump",
+		"-h", m.config.SrcHost,
+		"-p", fmt.Sprintf("%d", m.config.SrcPort),
+		"-U", m.config.SrcUser,
+		"-F", "c", // Custom format: the only format pg_restore can read from a pipe
+		"-b",
+		"-v",
+		dbname,
+	)
+	dumpCmd.Env = m.srcExecEnv()
+	dumpCmd.Stdout = pw
+
+	restoreCmd := exec.Command("pg_restore",
+		"-h", m.config.DstHost,
+		"-p", fmt.Sprintf("%d", m.config.DstPort),
+		"-U", m.config.DstUser,
+		"-d", dbname,
+		"-v",
+		"--no-owner",
+		"--no-acl",
+	)
+	restoreCmd.Env = m.dstExecEnv()
+	restoreCmd.Stdin = pr
+
+	dumpStderr, err := dumpCmd.StderrPipe()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to attach pg_dump stderr pipe: %w", err)
 	}
-	
-	log.Printf("\nFound %d databases to migrate: %s", len(databases), strings.Join(databases, ", "))
-	
-	// Create dump directory
-	if err := os.MkdirAll(m.config.DumpDir, 0755); err != nil {
-		return fmt.Errorf("failed to create dump directory: %w", err)
+	restoreStderr, err := restoreCmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to attach pg_restore stderr pipe: %w", err)
 	}
-	
-	for _, dbname := range databases {
-		log.Printf("\n%s", strings.Repeat("=", 60))
-		log.Printf("Migrating database: %s", dbname)
-		log.Printf("%s", strings.Repeat("=", 60))
-		
-		// Get owner
-		owner, err := m.GetDatabaseOwner(dbname)
-		if err != nil {
-			log.Printf("⨯ Failed to get owner for %s: %v", dbname, err)
-			continue
-		}
-		log.Printf("Database owner: %s", owner)
-		
-		// Create database
+
+	if err := restoreCmd.Start(); err != nil {
+		return fmt.Errorf("failed to start pg_restore: %w", err)
+	}
+	if err := dumpCmd.Start(); err != nil {
+		return fmt.Errorf("failed to start pg_dump: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		logLines(fmt.Sprintf("pg_dump:%s", dbname), dumpStderr)
+	}()
+	go func() {
+		defer wg.Done()
+		logLines(fmt.Sprintf("pg_restore:%s", dbname), restoreStderr)
+	}()
+
+	dumpErr := dumpCmd.Wait()
+	pw.Close()
+	restoreErr := restoreCmd.Wait()
+	wg.Wait()
+
+	if dumpErr != nil {
+		return fmt.Errorf("pg_dump failed for %s: %w", dbname, dumpErr)
+	}
+	if restoreErr != nil {
+		return fmt.Errorf("pg_restore failed for %s: %w", dbname, restoreErr)
+	}
+
+	log.Printf("✓ Piped %s directly from pg_dump to pg_restore", dbname)
+	return nil
+}
+
+// migrateOneDatabase runs the full per-database pipeline (create, then
+// either pipe or dump+restore) with state checkpointing. It's run
+// concurrently across databases by MigrateDatabases, so it must not
+// share any per-database mutable state.
+func (m *Migrator) migrateOneDatabase(dbname string) {
+	log.Printf("\n%s", strings.Repeat("=", 60))
+	log.Printf("Migrating database: %s", dbname)
+	log.Printf("%s", strings.Repeat("=", 60))
+
+	if err := m.runHook("pre-database:"+dbname, dbname, "postgres"); err != nil {
+		log.Printf("⨯ pre-database hook failed for %s: %v", dbname, err)
+		return
+	}
+
+	owner, err := m.GetDatabaseOwner(dbname)
+	if err != nil {
+		log.Printf("⨯ Failed to get owner for %s: %v", dbname, err)
+		return
+	}
+	log.Printf("Database owner: %s", owner)
+
+	if m.alreadyDone("create-database", dbname) {
+		log.Printf("Skipping create-database %s (already done in a previous run)", dbname)
+	} else {
+		m.recordRunning("create-database", dbname)
 		if err := m.CreateDatabase(dbname, owner); err != nil {
 			log.Printf("⨯ Failed to create database %s: %v", dbname, err)
-			continue
-		}
-		
-		// Dump database
-		dumpFile := filepath.Join(m.config.DumpDir, fmt.Sprintf("%s.dump", dbname))
-		if err := m.DumpDatabase(dbname, dumpFile); err != nil {
-			log.Printf("⨯ Failed to dump %s: %v", dbname, err)
-			continue
+			m.recordFailed("create-database", dbname, err)
+			return
 		}
-		
-		// Restore database
-		if err := m.RestoreDatabase(dbname, dumpFile); err != nil {
-			log.Printf("⨯ Failed to restore %s: %v", dbname, err)
-			continue
+		m.recordDone("create-database", dbname)
+	}
+
+	if m.config.Pipe {
+		if m.alreadyDone("pipe-database", dbname) {
+			log.Printf("Skipping pipe-database %s (already done in a previous run)", dbname)
+		} else {
+			m.recordRunning("pipe-database", dbname)
+			if err := m.PipeDatabase(dbname); err != nil {
+				log.Printf("⨯ Failed to pipe %s: %v", dbname, err)
+				m.recordFailed("pipe-database", dbname, err)
+				return
+			}
+			m.recordDone("pipe-database", dbname)
 		}
-		
-		// Clean up dump file
-		if err := os.Remove(dumpFile); err != nil {
-			log.Printf("Warning: Failed to remove dump file %s: %v", dumpFile, err)
+
+		if err := m.runHook("post-database:"+dbname, dbname, dbname); err != nil {
+			log.Printf("⨯ post-database hook failed for %s: %v", dbname, err)
+			return
 		}
-		
+
 		log.Printf("✓ Successfully migrated %s", dbname)
+		return
 	}
-	
-	return nil
-}
 
-func (m *Migrator) Migrate() error {
-	log.Println("Starting migration process...")
-	
-	if err := m.Connect(); err != nil {
-		return err
+	dumpDir := filepath.Join(m.config.DumpDir, dbname)
+
+	if m.alreadyDone("dump-database", dbname) {
+		log.Printf("Skipping dump-database %s (already done in a previous run)", dbname)
+	} else {
+		m.recordRunning("dump-database", dbname)
+		if err := m.DumpDatabase(dbname, dumpDir); err != nil {
+			log.Printf("⨯ Failed to dump %s: %v", dbname, err)
+			m.recordFailed("dump-database", dbname, err)
+			return
+		}
+		m.recordDone("dump-database", dbname)
 	}
-	defer m.Close()
+
+	if m.alreadyDone("restore-database", dbname) {
+		log.Printf("Skipping restore-database %s (already done in a previous run)", dbname)
+	} else {
+		m.recordRunning("restore-database", dbname)
+		if err := m.RestoreDatabase(dbname, dumpDir); err != nil {
+			log.Printf("⨯ Failed to restore %s: %v", dbname, err)
+			m.recordFailed("restore-database", dbname, err)
+			return
+		}
+		m.recordDone("restore-database", dbname)
+	}
+
+	if err := os.RemoveAll(dumpDir); err != nil {
+		log.Printf("Warning: Failed to remove dump directory %s: %v", dumpDir, err)
+	}
+
+	if err := m.runHook("post-database:"+dbname, dbname, dbname); err != nil {
+		log.Printf("⨯ post-database hook failed for %s: %v", dbname, err)
+		return
+	}
+
+	log.Printf("✓ Successfully migrated %s", dbname)
+}
+
+func (m *Migrator) MigrateDatabases() error {
+	databases, err := m.GetDatabases()
+	if err != nil {
+		return err
+	}
+
+	databases = m.filterDatabases(databases)
+
+	log.Printf("\nFound %d databases to migrate: %s", len(databases), strings.Join(databases, ", "))
+
+	// Create dump directory
+	if err := os.MkdirAll(m.config.DumpDir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	jobsDatabases := m.config.JobsDatabases
+	if jobsDatabases < 1 {
+		jobsDatabases = 1
+	}
+
+	sem := make(chan struct{}, jobsDatabases)
+	var wg sync.WaitGroup
+
+	for _, dbname := range databases {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(dbname string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			m.migrateOneDatabase(dbname)
+		}(dbname)
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// RoleMembership models a row from pg_auth_members: "member" is a member
+// of "role", optionally WITH ADMIN OPTION.
+type RoleMembership struct {
+	Role        string
+	Member      string
+	AdminOption bool
+}
+
+// ObjectGrant is a single ACL entry exploded from a relation's ACL
+// (pg_class.relacl) or a function's ACL (pg_proc.proacl).
+type ObjectGrant struct {
+	Schema     string
+	ObjectName string
+	ObjectType string // "TABLE", "SEQUENCE", "FUNCTION"
+	Grantee    string
+	Privilege  string
+	Grantable  bool
+}
+
+// DefaultPrivilege is a single entry from pg_default_acl, describing
+// privileges granted automatically to objects created in the future.
+type DefaultPrivilege struct {
+	Owner      string
+	Schema     string // empty for database-wide defaults
+	ObjectType string // "TABLES", "SEQUENCES", "FUNCTIONS", "TYPES"
+	Grantee    string
+	Privilege  string
+	Grantable  bool
+}
+
+var defaultACLObjectType = map[string]string{
+	"r": "TABLES",
+	"S": "SEQUENCES",
+	"f": "FUNCTIONS",
+	"T": "TYPES",
+}
+
+// pgEnvVars mirrors a TLS/auth configuration into the PG* environment
+// variables pg_dump/pg_restore read, so the exec'd tool phases match the
+// in-process connection's security posture.
+func pgEnvVars(sslMode, sslRootCert, sslCert, sslKey, channelBinding string) []string {
+	var vars []string
+	if sslMode != "" {
+		vars = append(vars, fmt.Sprintf("PGSSLMODE=%s", sslMode))
+	}
+	if sslRootCert != "" {
+		vars = append(vars, fmt.Sprintf("PGSSLROOTCERT=%s", sslRootCert))
+	}
+	if sslCert != "" {
+		vars = append(vars, fmt.Sprintf("PGSSLCERT=%s", sslCert))
+	}
+	if sslKey != "" {
+		vars = append(vars, fmt.Sprintf("PGSSLKEY=%s", sslKey))
+	}
+	if channelBinding != "" {
+		vars = append(vars, fmt.Sprintf("PGCHANNELBINDING=%s", channelBinding))
+	}
+	return vars
+}
+
+func (m *Migrator) srcExecEnv() []string {
+	env := append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", m.config.SrcPassword))
+	return append(env, pgEnvVars(m.config.SrcSSLMode, m.config.SrcSSLRootCert, m.config.SrcSSLCert, m.config.SrcSSLKey, m.config.ChannelBinding)...)
+}
+
+func (m *Migrator) dstExecEnv() []string {
+	env := append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", m.config.DstPassword))
+	return append(env, pgEnvVars(m.config.DstSSLMode, m.config.DstSSLRootCert, m.config.DstSSLCert, m.config.DstSSLKey, m.config.ChannelBinding)...)
+}
+
+// dbnameOr returns name unless it's empty, in which case it returns
+// fallback. Phases that aren't scoped to a single database (roles)
+// still need something connectable to put in PGDATABASE for hooks.
+func dbnameOr(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// runHook executes every *.sql and executable *.sh/binary file under
+// m.hooks.Dir/<phase>, in lexical order, following the same idea as
+// trek's RunHook. SQL files run against the destination database named
+// sqlDBName; shell/binary files run with PGHOST/PGPORT/PGUSER/PGDATABASE
+// pointed at the destination and SRC_-prefixed equivalents pointed at
+// the source, so a single script can reach either side. A missing or
+// unconfigured hook directory is a no-op.
+func (m *Migrator) runHook(phase, dbname, sqlDBName string) error {
+	if m.hooks.Dir == "" {
+		return nil
+	}
+
+	dir := filepath.Join(m.hooks.Dir, phase)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read hook directory %s: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+
+		var hookErr error
+		if strings.HasSuffix(name, ".sql") {
+			hookErr = m.runSQLHook(path, sqlDBName)
+		} else {
+			info, statErr := os.Stat(path)
+			if statErr != nil {
+				hookErr = statErr
+			} else if info.Mode()&0111 == 0 {
+				log.Printf("Skipping non-executable hook %s", path)
+				continue
+			} else {
+				hookErr = m.runShellHook(path, dbnameOr(dbname, "postgres"))
+			}
+		}
+
+		if hookErr != nil {
+			if m.hooks.ContinueOnError {
+				log.Printf("⨯ Hook %s failed (continuing): %v", path, hookErr)
+				continue
+			}
+			return fmt.Errorf("hook %s failed: %w", path, hookErr)
+		}
+
+		log.Printf("✓ Ran hook %s", path)
+	}
+
+	return nil
+}
+
+// runSQLHook executes the contents of path as a single batch against the
+// destination database dbname.
+func (m *Migrator) runSQLHook(path, dbname string) error {
+	sqlBytes, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.connectDstDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Exec(string(sqlBytes))
+	return err
+}
+
+// runShellHook runs an executable hook with connection details for both
+// sides of the migration available as environment variables: PGHOST/
+// PGPORT/PGUSER/PGDATABASE for the destination, and SRC_PGHOST/
+// SRC_PGPORT/SRC_PGUSER/SRC_PGDATABASE for the source.
+func (m *Migrator) runShellHook(path, dbname string) error {
+	cmd := exec.Command(path)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("PGHOST=%s", m.config.DstHost),
+		fmt.Sprintf("PGPORT=%d", m.config.DstPort),
+		fmt.Sprintf("PGUSER=%s", m.config.DstUser),
+		fmt.Sprintf("PGDATABASE=%s", dbname),
+		fmt.Sprintf("SRC_PGHOST=%s", m.config.SrcHost),
+		fmt.Sprintf("SRC_PGPORT=%d", m.config.SrcPort),
+		fmt.Sprintf("SRC_PGUSER=%s", m.config.SrcUser),
+		fmt.Sprintf("SRC_PGDATABASE=%s", dbname),
+	)
+	return streamCommand(cmd, fmt.Sprintf("hook:%s", filepath.Base(path)))
+}
+
+// buildConnString assembles a libpq keyword/value connection string,
+// used when the user configured individual host/port/user flags instead
+// of a full --src-uri/--dst-uri.
+func buildConnString(host string, port int, user, password, dbname, sslMode, sslRootCert, sslCert, sslKey, channelBinding string) string {
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	parts := []string{
+		fmt.Sprintf("host=%s", host),
+		fmt.Sprintf("port=%d", port),
+		fmt.Sprintf("user=%s", user),
+		fmt.Sprintf("password=%s", password),
+		fmt.Sprintf("dbname=%s", dbname),
+		fmt.Sprintf("sslmode=%s", sslMode),
+	}
+	if sslRootCert != "" {
+		parts = append(parts, fmt.Sprintf("sslrootcert=%s", sslRootCert))
+	}
+	if sslCert != "" {
+		parts = append(parts, fmt.Sprintf("sslcert=%s", sslCert))
+	}
+	if sslKey != "" {
+		parts = append(parts, fmt.Sprintf("sslkey=%s", sslKey))
+	}
+	if channelBinding != "" {
+		parts = append(parts, fmt.Sprintf("channel_binding=%s", channelBinding))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// withDatabase returns uri with its path replaced by dbname, so a single
+// user-supplied --src-uri/--dst-uri can be reused to connect to each
+// database the migrator touches in turn.
+func withDatabase(uri, dbname string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse connection URI: %w", err)
+	}
+	u.Path = "/" + dbname
+	return u.String(), nil
+}
+
+func (m *Migrator) srcConnString(dbname string) (string, error) {
+	if m.config.SrcURI != "" {
+		return withDatabase(m.config.SrcURI, dbname)
+	}
+	return buildConnString(m.config.SrcHost, m.config.SrcPort, m.config.SrcUser, m.config.SrcPassword, dbname,
+		m.config.SrcSSLMode, m.config.SrcSSLRootCert, m.config.SrcSSLCert, m.config.SrcSSLKey, m.config.ChannelBinding), nil
+}
+
+func (m *Migrator) dstConnString(dbname string) (string, error) {
+	if m.config.DstURI != "" {
+		return withDatabase(m.config.DstURI, dbname)
+	}
+	return buildConnString(m.config.DstHost, m.config.DstPort, m.config.DstUser, m.config.DstPassword, dbname,
+		m.config.DstSSLMode, m.config.DstSSLRootCert, m.config.DstSSLCert, m.config.DstSSLKey, m.config.ChannelBinding), nil
+}
+
+func openAndPing(connStr, label string) (*sql.DB, error) {
+	conn, err := sql.Open("pgx", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", label, err)
+	}
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping %s: %w", label, err)
+	}
+
+	return conn, nil
+}
+
+// connectSrcDB opens a connection to a specific database on the source
+// server, honoring --src-uri if set or the individual --src-* flags
+// (including TLS and channel binding) otherwise.
+func (m *Migrator) connectSrcDB(dbname string) (*sql.DB, error) {
+	connStr, err := m.srcConnString(dbname)
+	if err != nil {
+		return nil, err
+	}
+	return openAndPing(connStr, dbname)
+}
+
+// connectDstDB is connectSrcDB's destination-side counterpart.
+func (m *Migrator) connectDstDB(dbname string) (*sql.DB, error) {
+	connStr, err := m.dstConnString(dbname)
+	if err != nil {
+		return nil, err
+	}
+	return openAndPing(connStr, dbname)
+}
+
+func (m *Migrator) GetRoleMemberships() ([]RoleMembership, error) {
+	query := `
+		SELECT r.rolname AS role, m.rolname AS member, am.admin_option
+		FROM pg_auth_members am
+		JOIN pg_roles r ON r.oid = am.roleid
+		JOIN pg_roles m ON m.oid = am.member
+		WHERE r.rolname NOT LIKE 'pg_%'
+		AND m.rolname NOT LIKE 'pg_%'
+		ORDER BY r.rolname, m.rolname;
+	`
+
+	rows, err := m.srcConn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role memberships: %w", err)
+	}
+	defer rows.Close()
+
+	var memberships []RoleMembership
+	for rows.Next() {
+		var rm RoleMembership
+		if err := rows.Scan(&rm.Role, &rm.Member, &rm.AdminOption); err != nil {
+			return nil, fmt.Errorf("failed to scan role membership: %w", err)
+		}
+		memberships = append(memberships, rm)
+	}
+
+	return memberships, nil
+}
+
+// ApplyRoleMemberships grants are idempotent in Postgres (granting a
+// membership a role already holds is a no-op), so no existence check is
+// needed before issuing GRANT.
+func (m *Migrator) ApplyRoleMemberships(memberships []RoleMembership) error {
+	for _, rm := range memberships {
+		stmt := fmt.Sprintf("GRANT %s TO %s", quoteIdent(rm.Role), quoteIdent(rm.Member))
+		if rm.AdminOption {
+			stmt += " WITH ADMIN OPTION"
+		}
+
+		if _, err := m.dstConn.Exec(stmt); err != nil {
+			log.Printf("⨯ Failed to grant membership %s -> %s: %v", rm.Role, rm.Member, err)
+			continue
+		}
+		log.Printf("✓ Granted role %s to %s", rm.Role, rm.Member)
+	}
+
+	return nil
+}
+
+// GetObjectGrants explodes the ACLs on tables/sequences (pg_class) and
+// functions (pg_proc) in dbname, returning every non-default, non-owner
+// grant so it can be replayed on the destination.
+func (m *Migrator) GetObjectGrants(dbname string) ([]ObjectGrant, error) {
+	conn, err := m.connectSrcDB(dbname)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	query := `
+		SELECT n.nspname, c.relname,
+			CASE c.relkind WHEN 'S' THEN 'SEQUENCE' ELSE 'TABLE' END AS objtype,
+			CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE acl.grantee::regrole::text END,
+			acl.privilege_type, acl.is_grantable
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL aclexplode(c.relacl) AS acl
+		WHERE c.relkind IN ('r', 'v', 'S', 'p')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND acl.grantee != c.relowner
+
+		UNION ALL
+
+		SELECT n.nspname, p.proname, 'FUNCTION',
+			CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE acl.grantee::regrole::text END,
+			acl.privilege_type, acl.is_grantable
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		CROSS JOIN LATERAL aclexplode(p.proacl) AS acl
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND acl.grantee != p.proowner
+
+		ORDER BY 1, 2, 3, 4, 5;
+	`
+
+	rows, err := conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query object grants in %s: %w", dbname, err)
+	}
+	defer rows.Close()
+
+	var grants []ObjectGrant
+	for rows.Next() {
+		var g ObjectGrant
+		if err := rows.Scan(&g.Schema, &g.ObjectName, &g.ObjectType, &g.Grantee, &g.Privilege, &g.Grantable); err != nil {
+			return nil, fmt.Errorf("failed to scan object grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+
+	return grants, nil
+}
+
+func (m *Migrator) ApplyObjectGrants(dbname string, grants []ObjectGrant) error {
+	conn, err := m.connectDstDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, g := range grants {
+		qualifiedName := quoteIdent(g.Schema) + "." + quoteIdent(g.ObjectName)
+		stmt := fmt.Sprintf("GRANT %s ON %s %s TO %s",
+			g.Privilege, g.ObjectType, qualifiedName, quoteGrantee(g.Grantee))
+		if g.Grantable {
+			stmt += " WITH GRANT OPTION"
+		}
+
+		if _, err := conn.Exec(stmt); err != nil {
+			log.Printf("⨯ Failed to apply grant %s on %s to %s: %v", g.Privilege, qualifiedName, g.Grantee, err)
+			continue
+		}
+	}
+	log.Printf("✓ Applied %d object grants in %s", len(grants), dbname)
+
+	return nil
+}
+
+// GetDefaultPrivileges reads pg_default_acl, which drives the
+// "ALTER DEFAULT PRIVILEGES" behaviour applied to objects created after
+// the migration runs.
+func (m *Migrator) GetDefaultPrivileges(dbname string) ([]DefaultPrivilege, error) {
+	conn, err := m.connectSrcDB(dbname)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	query := `
+		SELECT o.rolname, COALESCE(n.nspname, ''), d.defaclobjtype,
+			CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE acl.grantee::regrole::text END,
+			acl.privilege_type, acl.is_grantable
+		FROM pg_default_acl d
+		JOIN pg_roles o ON o.oid = d.defacluser
+		LEFT JOIN pg_namespace n ON n.oid = d.defaclnamespace
+		CROSS JOIN LATERAL aclexplode(d.defaclacl) AS acl
+		ORDER BY 1, 2, 3, 4, 5;
+	`
+
+	rows, err := conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query default privileges in %s: %w", dbname, err)
+	}
+	defer rows.Close()
+
+	var privileges []DefaultPrivilege
+	for rows.Next() {
+		var p DefaultPrivilege
+		var objtype string
+		if err := rows.Scan(&p.Owner, &p.Schema, &objtype, &p.Grantee, &p.Privilege, &p.Grantable); err != nil {
+			return nil, fmt.Errorf("failed to scan default privilege: %w", err)
+		}
+		p.ObjectType = defaultACLObjectType[objtype]
+		if p.ObjectType == "" {
+			continue
+		}
+		privileges = append(privileges, p)
+	}
+
+	return privileges, nil
+}
+
+func (m *Migrator) ApplyDefaultPrivileges(dbname string, privileges []DefaultPrivilege) error {
+	conn, err := m.connectDstDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, p := range privileges {
+		var stmt strings.Builder
+		fmt.Fprintf(&stmt, "ALTER DEFAULT PRIVILEGES FOR ROLE %s", quoteIdent(p.Owner))
+		if p.Schema != "" {
+			fmt.Fprintf(&stmt, " IN SCHEMA %s", quoteIdent(p.Schema))
+		}
+		fmt.Fprintf(&stmt, " GRANT %s ON %s TO %s", p.Privilege, p.ObjectType, quoteGrantee(p.Grantee))
+		if p.Grantable {
+			stmt.WriteString(" WITH GRANT OPTION")
+		}
+
+		if _, err := conn.Exec(stmt.String()); err != nil {
+			log.Printf("⨯ Failed to apply default privilege %s on %s to %s: %v", p.Privilege, p.ObjectType, p.Grantee, err)
+			continue
+		}
+	}
+	log.Printf("✓ Applied %d default privileges in %s", len(privileges), dbname)
+
+	return nil
+}
+
+// MigrateGrants copies role memberships and per-database ACLs (object
+// grants plus default privileges) from source to destination. It is
+// safe to re-run on its own after a dump/restore since every statement
+// it issues is idempotent.
+func (m *Migrator) MigrateGrants() error {
+	log.Println("\n=== Migrating Grants ===")
+
+	memberships, err := m.GetRoleMemberships()
+	if err != nil {
+		return fmt.Errorf("failed to get role memberships: %w", err)
+	}
+	log.Printf("Found %d role memberships to migrate", len(memberships))
+	if err := m.ApplyRoleMemberships(memberships); err != nil {
+		return fmt.Errorf("failed to apply role memberships: %w", err)
+	}
+
+	databases, err := m.GetDatabases()
+	if err != nil {
+		return err
+	}
+	databases = m.filterDatabases(databases)
+
+	for _, dbname := range databases {
+		grants, err := m.GetObjectGrants(dbname)
+		if err != nil {
+			log.Printf("⨯ Failed to get object grants for %s: %v", dbname, err)
+			continue
+		}
+		if err := m.ApplyObjectGrants(dbname, grants); err != nil {
+			log.Printf("⨯ Failed to apply object grants for %s: %v", dbname, err)
+		}
+
+		privileges, err := m.GetDefaultPrivileges(dbname)
+		if err != nil {
+			log.Printf("⨯ Failed to get default privileges for %s: %v", dbname, err)
+			continue
+		}
+		if err := m.ApplyDefaultPrivileges(dbname, privileges); err != nil {
+			log.Printf("⨯ Failed to apply default privileges for %s: %v", dbname, err)
+		}
+	}
+
+	return nil
+}
+
+// SequencePosition is a source sequence's current value, read from
+// pg_sequences so it can be replayed on the destination after cutover.
+type SequencePosition struct {
+	Schema string
+	Name   string
+	Value  int64
+}
+
+// checkLogicalReplicationPrereqs verifies the source is configured for
+// logical replication before MigrateLogical does any work.
+func (m *Migrator) checkLogicalReplicationPrereqs() error {
+	var walLevel string
+	if err := m.srcConn.QueryRow("SHOW wal_level").Scan(&walLevel); err != nil {
+		return fmt.Errorf("failed to read source wal_level: %w", err)
+	}
+	if walLevel != "logical" {
+		return fmt.Errorf("source wal_level is %q, must be \"logical\" for --mode=logical", walLevel)
+	}
+	return nil
+}
+
+// DumpSchemaOnly is like DumpDatabase but excludes data, used to seed
+// the destination schema before logical replication takes over copying
+// (and later streaming) the actual rows.
+func (m *Migrator) DumpSchemaOnly(dbname, dumpFile string) error {
+	log.Printf("Dumping schema only: %s", dbname)
+
+	cmd := exec.Command("pg_dump",
+		"-h", m.config.SrcHost,
+		"-p", fmt.Sprintf("%d", m.config.SrcPort),
+		"-U", m.config.SrcUser,
+		"-F", "c",
+		"--schema-only",
+		"-v",
+		"-f", dumpFile,
+		dbname,
+	)
+
+	cmd.Env = m.srcExecEnv()
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pg_dump --schema-only failed: %w\n%s", err, string(output))
+	}
+
+	log.Printf("✓ Dumped schema for %s to %s", dbname, dumpFile)
+	return nil
+}
+
+// CreatePublication creates a FOR ALL TABLES publication on the source
+// database dbname, skipping it if one already exists from a prior run.
+func (m *Migrator) CreatePublication(dbname string) error {
+	conn, err := m.connectSrcDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var exists bool
+	err = conn.QueryRow("SELECT EXISTS(SELECT 1 FROM pg_publication WHERE pubname = $1)", logicalPublicationName(dbname)).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check for existing publication: %w", err)
+	}
+	if exists {
+		log.Printf("Publication %s already exists on %s, skipping...", logicalPublicationName(dbname), dbname)
+		return nil
+	}
+
+	stmt := fmt.Sprintf("CREATE PUBLICATION %s FOR ALL TABLES", quoteIdent(logicalPublicationName(dbname)))
+	if _, err := conn.Exec(stmt); err != nil {
+		return fmt.Errorf("failed to create publication on %s: %w", dbname, err)
+	}
+
+	log.Printf("✓ Created publication %s on %s", logicalPublicationName(dbname), dbname)
+	return nil
+}
+
+// CreateSubscription creates a subscription on the destination database
+// dbname that streams from the matching source database via pgoutput.
+func (m *Migrator) CreateSubscription(dbname string) error {
+	conn, err := m.connectDstDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var exists bool
+	err = conn.QueryRow("SELECT EXISTS(SELECT 1 FROM pg_subscription WHERE subname = $1)", logicalSubscriptionName(dbname)).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check for existing subscription: %w", err)
+	}
+	if exists {
+		log.Printf("Subscription %s already exists on %s, skipping...", logicalSubscriptionName(dbname), dbname)
+		return nil
+	}
+
+	connInfo, err := m.srcConnString(dbname)
+	if err != nil {
+		return fmt.Errorf("failed to build source connection string for %s: %w", dbname, err)
+	}
+
+	stmt := fmt.Sprintf(
+		"CREATE SUBSCRIPTION %s CONNECTION %s PUBLICATION %s WITH (slot_name = %s, create_slot = true)",
+		quoteIdent(logicalSubscriptionName(dbname)), quoteLiteral(connInfo),
+		quoteIdent(logicalPublicationName(dbname)), quoteIdent(logicalSlotName(dbname)))
+
+	if _, err := conn.Exec(stmt); err != nil {
+		return fmt.Errorf("failed to create subscription on %s: %w", dbname, err)
+	}
+
+	log.Printf("✓ Created subscription %s on %s", logicalSubscriptionName(dbname), dbname)
+	return nil
+}
+
+// WaitForCatchup blocks, polling pg_replication_slots on the source,
+// until the replication lag for the migrator's slot is at or below
+// lagBytes, then returns.
+func (m *Migrator) WaitForCatchup(dbname string, lagBytes uint64) error {
+	conn, err := m.connectSrcDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `
+		SELECT pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn)::bigint
+		FROM pg_replication_slots
+		WHERE slot_name = $1;
+	`
+
+	log.Printf("Waiting for %s to catch up to within %d bytes of lag...", dbname, lagBytes)
+
+	for {
+		var lag int64
+		if err := conn.QueryRow(query, logicalSlotName(dbname)).Scan(&lag); err != nil {
+			return fmt.Errorf("failed to read replication lag for %s: %w", dbname, err)
+		}
+
+		if lag >= 0 && uint64(lag) <= lagBytes {
+			log.Printf("✓ %s caught up (lag %d bytes)", dbname, lag)
+			return nil
+		}
+
+		log.Printf("%s still catching up (lag %d bytes)", dbname, lag)
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// GetSequencePositions reads every sequence's current value on the
+// source database, used to fast-forward destination sequences at
+// cutover (logical replication doesn't replicate sequence state).
+func (m *Migrator) GetSequencePositions(dbname string) ([]SequencePosition, error) {
+	conn, err := m.connectSrcDB(dbname)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query("SELECT schemaname, sequencename, last_value FROM pg_sequences WHERE last_value IS NOT NULL;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sequence positions for %s: %w", dbname, err)
+	}
+	defer rows.Close()
+
+	var positions []SequencePosition
+	for rows.Next() {
+		var p SequencePosition
+		if err := rows.Scan(&p.Schema, &p.Name, &p.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan sequence position: %w", err)
+		}
+		positions = append(positions, p)
+	}
+
+	return positions, nil
+}
+
+// Cutover pauses writes on the source, waits for replication to fully
+// drain, then detaches the destination from logical replication and
+// fast-forwards its sequences to match the source. It is the final,
+// blocking step of a --mode=logical migration.
+func (m *Migrator) Cutover(dbname string) error {
+	log.Printf("Cutting over %s...", dbname)
+
+	if err := m.runHook("pre-cutover", dbname, dbname); err != nil {
+		return fmt.Errorf("pre-cutover hook failed for %s: %w", dbname, err)
+	}
+
+	srcConn, err := m.connectSrcDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	// Pause application writes via an advisory lock applications are
+	// expected to honor for the duration of cutover.
+	if _, err := srcConn.Exec("SELECT pg_advisory_lock(hashtext($1))", logicalPublicationName(dbname)); err != nil {
+		return fmt.Errorf("failed to acquire cutover advisory lock on %s: %w", dbname, err)
+	}
+	defer srcConn.Exec("SELECT pg_advisory_unlock(hashtext($1))", logicalPublicationName(dbname))
+
+	if err := m.WaitForCatchup(dbname, 0); err != nil {
+		return fmt.Errorf("failed waiting for final catchup on %s: %w", dbname, err)
+	}
+
+	dstConn, err := m.connectDstDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	// DROP SUBSCRIPTION connects to the source to drop its replication
+	// slot as part of the same command, as long as the source is still
+	// reachable (it was just polled in WaitForCatchup above). Detaching
+	// the slot first via "SET (slot_name = NONE)" would be the escape
+	// hatch for an unreachable source, but used here it would leave the
+	// slot behind on the source forever, holding back WAL.
+	subName := quoteIdent(logicalSubscriptionName(dbname))
+	if _, err := dstConn.Exec(fmt.Sprintf("ALTER SUBSCRIPTION %s DISABLE", subName)); err != nil {
+		return fmt.Errorf("failed to disable subscription on %s: %w", dbname, err)
+	}
+	if _, err := dstConn.Exec(fmt.Sprintf("DROP SUBSCRIPTION %s", subName)); err != nil {
+		return fmt.Errorf("failed to drop subscription on %s: %w", dbname, err)
+	}
+
+	positions, err := m.GetSequencePositions(dbname)
+	if err != nil {
+		return fmt.Errorf("failed to read sequence positions on %s: %w", dbname, err)
+	}
+	for _, p := range positions {
+		qualifiedName := quoteIdent(p.Schema) + "." + quoteIdent(p.Name)
+		if _, err := dstConn.Exec("SELECT setval($1, $2)", qualifiedName, p.Value); err != nil {
+			log.Printf("⨯ Failed to reset sequence %s on %s: %v", qualifiedName, dbname, err)
+		}
+	}
+
+	log.Printf("✓ Cut over %s (%d sequences reset)", dbname, len(positions))
+
+	if err := m.runHook("post-cutover", dbname, dbname); err != nil {
+		return fmt.Errorf("post-cutover hook failed for %s: %w", dbname, err)
+	}
+
+	return nil
+}
+
+// MigrateLogical implements --mode=logical: schema-only migration per
+// database followed by a continuous publication/subscription pair, so
+// cutover can happen with near-zero downtime instead of a full dump/restore.
+func (m *Migrator) MigrateLogical() error {
+	log.Println("\n=== Migrating via logical replication ===")
+
+	if err := m.checkLogicalReplicationPrereqs(); err != nil {
+		return err
+	}
+
+	databases, err := m.GetDatabases()
+	if err != nil {
+		return err
+	}
+	databases = m.filterDatabases(databases)
+
+	if err := os.MkdirAll(m.config.DumpDir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	for _, dbname := range databases {
+		owner, err := m.GetDatabaseOwner(dbname)
+		if err != nil {
+			log.Printf("⨯ Failed to get owner for %s: %v", dbname, err)
+			continue
+		}
+
+		if err := m.CreateDatabase(dbname, owner); err != nil {
+			log.Printf("⨯ Failed to create database %s: %v", dbname, err)
+			continue
+		}
+
+		dumpFile := filepath.Join(m.config.DumpDir, fmt.Sprintf("%s.schema.dump", dbname))
+		if err := m.DumpSchemaOnly(dbname, dumpFile); err != nil {
+			log.Printf("⨯ Failed to dump schema for %s: %v", dbname, err)
+			continue
+		}
+		if err := m.RestoreDatabase(dbname, dumpFile); err != nil {
+			log.Printf("⨯ Failed to restore schema for %s: %v", dbname, err)
+			continue
+		}
+
+		if err := m.CreatePublication(dbname); err != nil {
+			log.Printf("⨯ Failed to create publication for %s: %v", dbname, err)
+			continue
+		}
+		if err := m.CreateSubscription(dbname); err != nil {
+			log.Printf("⨯ Failed to create subscription for %s: %v", dbname, err)
+			continue
+		}
+
+		if err := m.WaitForCatchup(dbname, m.config.LogicalLagBytes); err != nil {
+			log.Printf("⨯ %s did not catch up: %v", dbname, err)
+			continue
+		}
+
+		if m.config.Cutover {
+			if err := m.Cutover(dbname); err != nil {
+				log.Printf("⨯ Failed to cut over %s: %v", dbname, err)
+			}
+		} else {
+			log.Printf("%s is replicating; run with --cutover when ready to finish the migration", dbname)
+		}
+	}
+
+	return nil
+}
+
+// VerifyIssue is a single piece of drift found by Verify: something
+// missing on the destination, extra on the destination, or present on
+// both sides but with different values.
+type VerifyIssue struct {
+	Kind     string `json:"kind"` // "missing", "extra", "divergent"
+	Category string `json:"category"`
+	Object   string `json:"object"`
+	Detail   string `json:"detail"`
+}
+
+// VerifyReport is the machine-readable output of Verify(), written to
+// --report-file so it can gate a cutover in CI/CD.
+type VerifyReport struct {
+	GeneratedAt string        `json:"generated_at"`
+	Issues      []VerifyIssue `json:"issues"`
+}
+
+func (r *VerifyReport) add(kind, category, object, detail string) {
+	r.Issues = append(r.Issues, VerifyIssue{Kind: kind, Category: category, Object: object, Detail: detail})
+}
+
+// verifyRoles diffs the role list and attributes between source and
+// destination.
+func (m *Migrator) verifyRoles(report *VerifyReport) error {
+	srcRoles, err := rolesFromConn(m.srcConn)
+	if err != nil {
+		return fmt.Errorf("failed to read source roles: %w", err)
+	}
+	dstRoles, err := rolesFromConn(m.dstConn)
+	if err != nil {
+		return fmt.Errorf("failed to read destination roles: %w", err)
+	}
+
+	dstByName := make(map[string]Role, len(dstRoles))
+	for _, r := range dstRoles {
+		dstByName[r.Name] = r
+	}
+
+	srcByName := make(map[string]bool, len(srcRoles))
+	for _, sr := range srcRoles {
+		srcByName[sr.Name] = true
+
+		dr, ok := dstByName[sr.Name]
+		if !ok {
+			report.add("missing", "role", sr.Name, "role exists on source but not destination")
+			continue
+		}
+
+		if sr != dr {
+			report.add("divergent", "role", sr.Name, fmt.Sprintf("source=%+v destination=%+v", sr, dr))
+		}
+	}
+
+	for _, dr := range dstRoles {
+		if !srcByName[dr.Name] {
+			report.add("extra", "role", dr.Name, "role exists on destination but not source")
+		}
+	}
+
+	return nil
+}
+
+// verifyDatabases diffs the database list and owners between source and
+// destination.
+func (m *Migrator) verifyDatabases(report *VerifyReport) ([]string, error) {
+	srcDatabases, err := databasesFromConn(m.srcConn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source databases: %w", err)
+	}
+	dstDatabases, err := databasesFromConn(m.dstConn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destination databases: %w", err)
+	}
+
+	dstSet := make(map[string]bool, len(dstDatabases))
+	for _, d := range dstDatabases {
+		dstSet[d] = true
+	}
+
+	srcSet := make(map[string]bool, len(srcDatabases))
+	var common []string
+	for _, dbname := range srcDatabases {
+		srcSet[dbname] = true
+
+		if !dstSet[dbname] {
+			report.add("missing", "database", dbname, "database exists on source but not destination")
+			continue
+		}
+		common = append(common, dbname)
+
+		srcOwner, err := databaseOwnerFromConn(m.srcConn, dbname)
+		if err != nil {
+			return nil, err
+		}
+		dstOwner, err := databaseOwnerFromConn(m.dstConn, dbname)
+		if err != nil {
+			return nil, err
+		}
+		if srcOwner != dstOwner {
+			report.add("divergent", "database", dbname, fmt.Sprintf("owner source=%s destination=%s", srcOwner, dstOwner))
+		}
+	}
+
+	for _, dbname := range dstDatabases {
+		if !srcSet[dbname] {
+			report.add("extra", "database", dbname, "database exists on destination but not source")
+		}
+	}
+
+	return m.filterDatabases(common), nil
+}
+
+// verifyTables diffs per-table row counts and on-disk size for every
+// user table in dbname.
+func (m *Migrator) verifyTables(report *VerifyReport, dbname string) error {
+	srcConn, err := m.connectSrcDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	dstConn, err := m.connectDstDB(dbname)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	rows, err := srcConn.Query(`
+		SELECT schemaname, tablename FROM pg_tables
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY 1, 2;
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list tables in %s: %w", dbname, err)
+	}
+	defer rows.Close()
+
+	var tables [][2]string
+	for rows.Next() {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return fmt.Errorf("failed to scan table name: %w", err)
+		}
+		tables = append(tables, [2]string{schema, table})
+	}
+
+	for _, t := range tables {
+		qualifiedName := quoteIdent(t[0]) + "." + quoteIdent(t[1])
+		object := dbname + "." + t[0] + "." + t[1]
+
+		var dstExists bool
+		err := dstConn.QueryRow("SELECT EXISTS(SELECT 1 FROM pg_tables WHERE schemaname = $1 AND tablename = $2)", t[0], t[1]).Scan(&dstExists)
+		if err != nil {
+			return fmt.Errorf("failed to check for table %s on destination: %w", object, err)
+		}
+		if !dstExists {
+			report.add("missing", "table", object, "table exists on source but not destination")
+			continue
+		}
+
+		var srcCount, dstCount int64
+		if err := srcConn.QueryRow(fmt.Sprintf("SELECT count(*) FROM %s", qualifiedName)).Scan(&srcCount); err != nil {
+			return fmt.Errorf("failed to count rows in source %s: %w", object, err)
+		}
+		if err := dstConn.QueryRow(fmt.Sprintf("SELECT count(*) FROM %s", qualifiedName)).Scan(&dstCount); err != nil {
+			return fmt.Errorf("failed to count rows in destination %s: %w", object, err)
+		}
+
+		var srcSize, dstSize int64
+		if err := srcConn.QueryRow("SELECT pg_relation_size($1::regclass)", qualifiedName).Scan(&srcSize); err != nil {
+			return fmt.Errorf("failed to size source %s: %w", object, err)
+		}
+		if err := dstConn.QueryRow("SELECT pg_relation_size($1::regclass)", qualifiedName).Scan(&dstSize); err != nil {
+			return fmt.Errorf("failed to size destination %s: %w", object, err)
+		}
+
+		if srcCount != dstCount {
+			report.add("divergent", "table-count", object, fmt.Sprintf("source=%d destination=%d", srcCount, dstCount))
+		}
+		if srcSize != dstSize {
+			report.add("divergent", "table-size", object, fmt.Sprintf("source=%d destination=%d bytes", srcSize, dstSize))
+		}
+
+		log.Printf("%s: rows source=%d destination=%d, size source=%d destination=%d bytes", object, srcCount, dstCount, srcSize, dstSize)
+	}
+
+	return nil
+}
+
+// verifyChecksums compares md5(string_agg(t::text, '')) for each
+// user-supplied schema.table, catching content drift row counts alone
+// would miss.
+func (m *Migrator) verifyChecksums(report *VerifyReport, tables []string) error {
+	for _, qualified := range tables {
+		parts := strings.SplitN(qualified, ".", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid --verify-tables entry %q, expected schema.table", qualified)
+		}
+		quotedName := quoteIdent(parts[0]) + "." + quoteIdent(parts[1])
+
+		// Ordering by the row's own text representation (rather than
+		// leaving it to physical scan order, which pg_restore -j's
+		// parallel restore doesn't preserve) gives both sides the same
+		// deterministic order without needing to look up a primary key.
+		query := fmt.Sprintf("SELECT md5(string_agg(t::text, '' ORDER BY t::text)) FROM %s t", quotedName)
+
+		var srcSum, dstSum sql.NullString
+		if err := m.srcConn.QueryRow(query).Scan(&srcSum); err != nil {
+			return fmt.Errorf("failed to checksum source %s: %w", qualified, err)
+		}
+		if err := m.dstConn.QueryRow(query).Scan(&dstSum); err != nil {
+			return fmt.Errorf("failed to checksum destination %s: %w", qualified, err)
+		}
+
+		if srcSum.String != dstSum.String {
+			report.add("divergent", "table-checksum", qualified, fmt.Sprintf("source=%s destination=%s", srcSum.String, dstSum.String))
+		}
+	}
+
+	return nil
+}
+
+// Verify compares source and destination and returns a report of
+// drift: missing/extra roles and databases, owner mismatches, per-table
+// row count and size differences, and (if --verify-tables is set)
+// content checksums. A non-empty report means the destination isn't
+// safe to cut over to yet.
+func (m *Migrator) Verify() (*VerifyReport, error) {
+	log.Println("\n=== Verifying migration ===")
+
+	report := &VerifyReport{GeneratedAt: time.Now().UTC().Format(time.RFC3339)}
+
+	if err := m.verifyRoles(report); err != nil {
+		return nil, fmt.Errorf("failed to verify roles: %w", err)
+	}
+
+	common, err := m.verifyDatabases(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify databases: %w", err)
+	}
+
+	for _, dbname := range common {
+		if err := m.verifyTables(report, dbname); err != nil {
+			log.Printf("⨯ Failed to verify tables in %s: %v", dbname, err)
+		}
+	}
+
+	if m.config.VerifyTables != "" {
+		tables := strings.Split(m.config.VerifyTables, ",")
+		for i := range tables {
+			tables[i] = strings.TrimSpace(tables[i])
+		}
+		if err := m.verifyChecksums(report, tables); err != nil {
+			return nil, fmt.Errorf("failed to verify checksums: %w", err)
+		}
+	}
+
+	log.Printf("Verification found %d issue(s)", len(report.Issues))
+	return report, nil
+}
+
+func (m *Migrator) Migrate() error {
+	log.Println("Starting migration process...")
 	
-	// Migrate roles
-	if err := m.MigrateRoles(); err != nil {
-		return fmt.Errorf("failed to migrate roles: %w", err)
+	if err := m.Connect(); err != nil {
+		return err
 	}
+	defer m.Close()
 	
+	// Migrate roles
+	if m.runPhase("roles") {
+		if err := m.MigrateRoles(); err != nil {
+			return fmt.Errorf("failed to migrate roles: %w", err)
+		}
+	} else {
+		log.Println("Skipping roles phase (--only)")
+	}
+
 	// Migrate databases
-	if err := m.MigrateDatabases(); err != nil {
-		return fmt.Errorf("failed to migrate databases: %w", err)
+	if m.runPhase("databases") {
+		if err := m.MigrateDatabases(); err != nil {
+			return fmt.Errorf("failed to migrate databases: %w", err)
+		}
+	} else {
+		log.Println("Skipping databases phase (--only)")
 	}
-	
+
+	// Migrate grants (ACLs, role memberships, default privileges)
+	if m.runPhase("grants") {
+		if err := m.MigrateGrants(); err != nil {
+			return fmt.Errorf("failed to migrate grants: %w", err)
+		}
+	} else {
+		log.Println("Skipping grants phase (--only)")
+	}
+
 	log.Println("\n" + strings.Repeat("=", 60))
 	log.Println("Migration completed!")
 	log.Println(strings.Repeat("=", 60))
@@ -493,17 +2064,119 @@ func main() {
 	flag.StringVar(&config.DstPassword, "dst-password", "", "Destination server password")
 	
 	flag.StringVar(&config.DumpDir, "dump-dir", "/tmp/pg_migration", "Directory for temporary dump files")
-	
+
+	var migrateGrantsOnly bool
+	flag.BoolVar(&migrateGrantsOnly, "migrate-grants", false, "Only run the grants migration phase (role memberships, ACLs, default privileges); skip roles and databases")
+
+	flag.BoolVar(&config.Resume, "resume", false, "Resume the last run, skipping objects already marked done in pg_migrator_state")
+	flag.StringVar(&config.OnlyPhases, "only", "", "Comma-separated list of phases to run: roles,databases,grants (default: all)")
+	flag.StringVar(&config.OnlyDatabases, "only-db", "", "Comma-separated list of databases to migrate (default: all)")
+	flag.StringVar(&config.SkipDatabases, "skip-db", "", "Comma-separated list of databases to skip")
+
+	flag.StringVar(&config.Mode, "mode", "dump-restore", "Migration mode: dump-restore (default) or logical (near-zero-downtime via logical replication)")
+	flag.Uint64Var(&config.LogicalLagBytes, "logical-lag-bytes", 1048576, "In --mode=logical, replication lag (bytes) considered caught up")
+	flag.BoolVar(&config.Cutover, "cutover", false, "In --mode=logical, cut over automatically once each database catches up")
+
+	flag.IntVar(&config.JobsDatabases, "jobs-databases", 1, "Number of databases to migrate concurrently")
+	flag.IntVar(&config.JobsPerDatabase, "jobs-per-database", 1, "Number of parallel pg_dump/pg_restore workers per database")
+	flag.BoolVar(&config.Pipe, "pipe", false, "Stream pg_dump directly into pg_restore via a pipe, skipping the temp dump file")
+
+	flag.BoolVar(&config.Verify, "verify", false, "Run verification only: diff source against destination and exit non-zero on drift")
+	flag.StringVar(&config.VerifyTables, "verify-tables", "", "Comma-separated schema.table list to checksum during --verify")
+	flag.StringVar(&config.ReportFile, "report-file", "", "Write the --verify JSON report to this path")
+
+	flag.StringVar(&config.SrcURI, "src-uri", "", "Full postgres:// connection URI for the source server (overrides -src-* flags)")
+	flag.StringVar(&config.SrcSSLMode, "src-sslmode", "", "Source SSL mode (disable, require, verify-ca, verify-full)")
+	flag.StringVar(&config.SrcSSLRootCert, "src-sslrootcert", "", "Path to source SSL root certificate")
+	flag.StringVar(&config.SrcSSLCert, "src-sslcert", "", "Path to source SSL client certificate")
+	flag.StringVar(&config.SrcSSLKey, "src-sslkey", "", "Path to source SSL client key")
+
+	flag.StringVar(&config.DstURI, "dst-uri", "", "Full postgres:// connection URI for the destination server (overrides -dst-* flags)")
+	flag.StringVar(&config.DstSSLMode, "dst-sslmode", "", "Destination SSL mode (disable, require, verify-ca, verify-full)")
+	flag.StringVar(&config.DstSSLRootCert, "dst-sslrootcert", "", "Path to destination SSL root certificate")
+	flag.StringVar(&config.DstSSLCert, "dst-sslcert", "", "Path to destination SSL client certificate")
+	flag.StringVar(&config.DstSSLKey, "dst-sslkey", "", "Path to destination SSL client key")
+
+	flag.StringVar(&config.ChannelBinding, "channel-binding", "", "SCRAM channel binding mode: require, prefer, or disable")
+
+	flag.StringVar(&config.HookDir, "hook-dir", "", "Directory of per-phase hooks (pre-roles, post-roles, pre-database:<name>, post-database:<name>, pre-cutover, post-cutover)")
+	flag.BoolVar(&config.ContinueOnHookError, "continue-on-hook-error", false, "Log and continue instead of aborting the phase when a hook fails")
+
 	flag.Parse()
-	
-	// Validate required flags
-	if config.SrcHost == "" || config.SrcUser == "" || config.SrcPassword == "" ||
-		config.DstHost == "" || config.DstUser == "" || config.DstPassword == "" {
+
+	// Validate required flags. A full connection URI stands in for its
+	// individual host/user/password flags.
+	srcConfigured := config.SrcURI != "" || (config.SrcHost != "" && config.SrcUser != "" && config.SrcPassword != "")
+	dstConfigured := config.DstURI != "" || (config.DstHost != "" && config.DstUser != "" && config.DstPassword != "")
+	if !srcConfigured || !dstConfigured {
 		log.Fatal("Missing required flags. Use -h for help.")
 	}
-	
+
 	migrator := NewMigrator(config)
-	
+
+	if migrateGrantsOnly {
+		if err := migrator.Connect(); err != nil {
+			log.Fatalf("Connection failed: %v", err)
+		}
+		defer migrator.Close()
+
+		if err := migrator.MigrateGrants(); err != nil {
+			log.Fatalf("Grants migration failed: %v", err)
+		}
+		return
+	}
+
+	if config.Verify {
+		if err := migrator.Connect(); err != nil {
+			log.Fatalf("Connection failed: %v", err)
+		}
+		defer migrator.Close()
+
+		report, err := migrator.Verify()
+		if err != nil {
+			log.Fatalf("Verification failed: %v", err)
+		}
+
+		if config.ReportFile != "" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				log.Fatalf("Failed to marshal verification report: %v", err)
+			}
+			if err := os.WriteFile(config.ReportFile, data, 0644); err != nil {
+				log.Fatalf("Failed to write verification report: %v", err)
+			}
+			log.Printf("Wrote verification report to %s", config.ReportFile)
+		}
+
+		if len(report.Issues) > 0 {
+			for _, issue := range report.Issues {
+				log.Printf("⨯ [%s] %s %s: %s", issue.Kind, issue.Category, issue.Object, issue.Detail)
+			}
+			os.Exit(1)
+		}
+
+		log.Println("✓ No drift detected")
+		return
+	}
+
+	if config.Mode == "logical" {
+		if err := migrator.Connect(); err != nil {
+			log.Fatalf("Connection failed: %v", err)
+		}
+		defer migrator.Close()
+
+		if migrator.runPhase("roles") {
+			if err := migrator.MigrateRoles(); err != nil {
+				log.Fatalf("Roles migration failed: %v", err)
+			}
+		}
+
+		if err := migrator.MigrateLogical(); err != nil {
+			log.Fatalf("Logical migration failed: %v", err)
+		}
+		return
+	}
+
 	if err := migrator.Migrate(); err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}